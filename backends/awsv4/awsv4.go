@@ -0,0 +1,223 @@
+// Package awsv4 implements backends.Backend against any S3-compatible
+// provider that speaks SigV4 and virtual-hosted-style addressing: AWS
+// itself, as well as MinIO and Ceph RGW when given a custom endpoint.
+package awsv4
+
+import (
+  "context"
+  "io"
+
+  "github.com/aws/aws-sdk-go-v2/aws"
+  "github.com/aws/aws-sdk-go-v2/config"
+  "github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+  "github.com/aws/aws-sdk-go-v2/service/s3"
+  "github.com/aws/aws-sdk-go-v2/service/s3/types"
+  "github.com/djannot/ecss3copy/backends"
+)
+
+// Backend is a backends.Backend implemented against aws-sdk-go-v2.
+type Backend struct {
+  client *s3.Client
+  uploader *manager.Uploader
+}
+
+// New creates a Backend for the given region/profile, optionally pointed at
+// a custom endpoint (for MinIO/Ceph RGW) with virtual-hosted-style addressing.
+func New(ctx context.Context, region, profile, endpoint string) (*Backend, error) {
+  loadOpts := []func(*config.LoadOptions) error{config.WithRegion(region)}
+  if profile != "" {
+    loadOpts = append(loadOpts, config.WithSharedConfigProfile(profile))
+  }
+  cfg, err := config.LoadDefaultConfig(ctx, loadOpts...)
+  if err != nil {
+    return nil, err
+  }
+
+  client := s3.NewFromConfig(cfg, func(o *s3.Options) {
+    if endpoint != "" {
+      o.BaseEndpoint = aws.String(endpoint)
+    }
+    o.UsePathStyle = false
+  })
+  return &Backend{
+    client: client,
+    uploader: manager.NewUploader(client),
+  }, nil
+}
+
+func (b *Backend) List(bucket, prefix, marker string, maxKeys int) (*backends.ListPage, error) {
+  out, err := b.client.ListObjects(context.Background(), &s3.ListObjectsInput{
+    Bucket: &bucket,
+    Prefix: &prefix,
+    Marker: &marker,
+    MaxKeys: aws.Int32(int32(maxKeys)),
+  })
+  if err != nil {
+    return nil, err
+  }
+  page := &backends.ListPage{IsTruncated: aws.ToBool(out.IsTruncated)}
+  for _, obj := range out.Contents {
+    page.Objects = append(page.Objects, backends.Object{
+      Key: aws.ToString(obj.Key),
+      Size: aws.ToInt64(obj.Size),
+      ETag: aws.ToString(obj.ETag),
+    })
+  }
+  if out.NextMarker != nil {
+    page.NextMarker = *out.NextMarker
+  } else if len(page.Objects) > 0 {
+    page.NextMarker = page.Objects[len(page.Objects)-1].Key
+  }
+  return page, nil
+}
+
+func (b *Backend) ListVersions(bucket, prefix, keyMarker, versionIdMarker string, maxKeys int) (*backends.VersionPage, error) {
+  out, err := b.client.ListObjectVersions(context.Background(), &s3.ListObjectVersionsInput{
+    Bucket: &bucket,
+    Prefix: &prefix,
+    KeyMarker: &keyMarker,
+    VersionIdMarker: &versionIdMarker,
+    MaxKeys: aws.Int32(int32(maxKeys)),
+  })
+  if err != nil {
+    return nil, err
+  }
+  page := &backends.VersionPage{
+    IsTruncated: aws.ToBool(out.IsTruncated),
+    NextKeyMarker: aws.ToString(out.NextKeyMarker),
+    NextVersionIdMarker: aws.ToString(out.NextVersionIdMarker),
+  }
+  for _, v := range out.Versions {
+    page.Objects = append(page.Objects, backends.Object{
+      Key: aws.ToString(v.Key),
+      Size: aws.ToInt64(v.Size),
+      ETag: aws.ToString(v.ETag),
+      VersionId: aws.ToString(v.VersionId),
+    })
+  }
+  for _, d := range out.DeleteMarkers {
+    page.Objects = append(page.Objects, backends.Object{
+      Key: aws.ToString(d.Key),
+      VersionId: aws.ToString(d.VersionId),
+      IsDeleteMarker: true,
+    })
+  }
+  return page, nil
+}
+
+func (b *Backend) Head(bucket, key string) (*backends.HeadInfo, error) {
+  out, err := b.client.HeadObject(context.Background(), &s3.HeadObjectInput{Bucket: &bucket, Key: &key})
+  if err != nil {
+    return nil, err
+  }
+  return &backends.HeadInfo{
+    ServerSideEncryption: string(out.ServerSideEncryption),
+    SSEKMSKeyId: aws.ToString(out.SSEKMSKeyId),
+    ContentLength: aws.ToInt64(out.ContentLength),
+    ETag: aws.ToString(out.ETag),
+  }, nil
+}
+
+func (b *Backend) Copy(sourceBucket, sourceKey, targetBucket, targetKey string, opts backends.CopyOptions) error {
+  copySource := sourceBucket + "/" + sourceKey
+  if opts.SourceVersionId != "" {
+    copySource += "?versionId=" + opts.SourceVersionId
+  }
+  input := &s3.CopyObjectInput{
+    Bucket: &targetBucket,
+    Key: &targetKey,
+    CopySource: &copySource,
+    ACL: types.ObjectCannedACL(opts.ACL),
+    MetadataDirective: types.MetadataDirective(opts.MetadataDirective),
+  }
+  if opts.ServerSideEncryption != "" {
+    input.ServerSideEncryption = types.ServerSideEncryption(opts.ServerSideEncryption)
+    if opts.SSEKMSKeyId != "" {
+      input.SSEKMSKeyId = &opts.SSEKMSKeyId
+    }
+  }
+  if opts.SSECustomerAlgorithm != "" {
+    input.SSECustomerAlgorithm = &opts.SSECustomerAlgorithm
+    input.SSECustomerKey = &opts.SSECustomerKey
+    input.SSECustomerKeyMD5 = &opts.SSECustomerKeyMD5
+  }
+  if opts.CopySourceSSECustomerAlgorithm != "" {
+    input.CopySourceSSECustomerAlgorithm = &opts.CopySourceSSECustomerAlgorithm
+    input.CopySourceSSECustomerKey = &opts.CopySourceSSECustomerKey
+    input.CopySourceSSECustomerKeyMD5 = &opts.CopySourceSSECustomerKeyMD5
+  }
+  _, err := b.client.CopyObject(context.Background(), input)
+  return err
+}
+
+func (b *Backend) Get(bucket, key string, opts backends.CopyOptions) (io.ReadCloser, int64, error) {
+  input := &s3.GetObjectInput{Bucket: &bucket, Key: &key}
+  if opts.CopySourceSSECustomerAlgorithm != "" {
+    input.SSECustomerAlgorithm = &opts.CopySourceSSECustomerAlgorithm
+    input.SSECustomerKey = &opts.CopySourceSSECustomerKey
+    input.SSECustomerKeyMD5 = &opts.CopySourceSSECustomerKeyMD5
+  }
+  out, err := b.client.GetObject(context.Background(), input)
+  if err != nil {
+    return nil, 0, err
+  }
+  return out.Body, aws.ToInt64(out.ContentLength), nil
+}
+
+// applySSE sets input's encryption fields from opts, matching how Copy
+// applies the same CopyOptions to a CopyObjectInput.
+func applySSE(input *s3.PutObjectInput, opts backends.CopyOptions) {
+  if opts.ServerSideEncryption != "" {
+    input.ServerSideEncryption = types.ServerSideEncryption(opts.ServerSideEncryption)
+    if opts.SSEKMSKeyId != "" {
+      input.SSEKMSKeyId = &opts.SSEKMSKeyId
+    }
+  }
+  if opts.SSECustomerAlgorithm != "" {
+    input.SSECustomerAlgorithm = &opts.SSECustomerAlgorithm
+    input.SSECustomerKey = &opts.SSECustomerKey
+    input.SSECustomerKeyMD5 = &opts.SSECustomerKeyMD5
+  }
+}
+
+// Put uploads body to bucket/key. Objects at or above opts.MultipartThreshold
+// are uploaded in opts.PartSize chunks via the SDK's multipart manager;
+// smaller objects go through a single PutObject call.
+func (b *Backend) Put(bucket, key string, body io.Reader, size int64, opts backends.CopyOptions) error {
+  threshold := opts.MultipartThreshold
+  if threshold <= 0 {
+    threshold = 100 * 1024 * 1024
+  }
+  if size < threshold {
+    input := &s3.PutObjectInput{
+      Bucket: &bucket,
+      Key: &key,
+      Body: body,
+      ACL: types.ObjectCannedACL(opts.ACL),
+    }
+    applySSE(input, opts)
+    _, err := b.client.PutObject(context.Background(), input)
+    return err
+  }
+
+  uploader := b.uploader
+  if opts.PartSize > 0 {
+    uploader = manager.NewUploader(b.client, func(u *manager.Uploader) {
+      u.PartSize = opts.PartSize
+    })
+  }
+  input := &s3.PutObjectInput{
+    Bucket: &bucket,
+    Key: &key,
+    Body: body,
+    ACL: types.ObjectCannedACL(opts.ACL),
+  }
+  applySSE(input, opts)
+  _, err := uploader.Upload(context.Background(), input)
+  return err
+}
+
+func (b *Backend) Delete(bucket, key string) error {
+  _, err := b.client.DeleteObject(context.Background(), &s3.DeleteObjectInput{Bucket: &bucket, Key: &key})
+  return err
+}
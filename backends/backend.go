@@ -0,0 +1,105 @@
+// Package backends defines the storage-provider-agnostic interface the tool
+// copies through, so that sources and targets don't both have to be the
+// same ECS instance. backends/ecs implements it against ECS's goamz-derived
+// S3 API; backends/awsv4 implements it against AWS (or any SigV4-compatible
+// provider, e.g. MinIO or Ceph RGW) via aws-sdk-go-v2.
+package backends
+
+import "io"
+
+// Object describes a single key returned by a listing, possibly a specific
+// historical version of it.
+type Object struct {
+  Key string
+  Size int64
+  ETag string
+  LastModified string
+  VersionId string
+  IsDeleteMarker bool
+}
+
+// ListPage is one page of a bucket listing.
+type ListPage struct {
+  Objects []Object
+  IsTruncated bool
+  NextMarker string
+}
+
+// VersionPage is one page of a bucket's version history.
+type VersionPage struct {
+  Objects []Object
+  IsTruncated bool
+  NextKeyMarker string
+  NextVersionIdMarker string
+}
+
+// HeadInfo is the subset of HeadObject metadata the tool reports on.
+type HeadInfo struct {
+  ServerSideEncryption string
+  SSEKMSKeyId string
+  ContentLength int64
+  ETag string
+}
+
+// CopyOptions controls how an object is written to its destination,
+// whichever backend that destination lives on.
+type CopyOptions struct {
+  ACL string
+  MetadataDirective string
+  SourceVersionId string
+
+  ServerSideEncryption string
+  SSEKMSKeyId string
+  SSECustomerAlgorithm string
+  SSECustomerKey string
+  SSECustomerKeyMD5 string
+  CopySourceSSECustomerAlgorithm string
+  CopySourceSSECustomerKey string
+  CopySourceSSECustomerKeyMD5 string
+
+  // MultipartThreshold and PartSize govern the awsv4 backend's Put: objects
+  // at or above MultipartThreshold bytes are uploaded in PartSize chunks
+  // rather than a single PUT. Zero means use the backend's default. The ecs
+  // backend's Put ignores both and always uploads in a single PUT.
+  MultipartThreshold int64
+  PartSize int64
+}
+
+// Backend is the set of operations the copy tool needs from a storage
+// provider. A single process may use different Backend implementations for
+// its source and target, e.g. to migrate from ECS to AWS.
+type Backend interface {
+  List(bucket, prefix, marker string, maxKeys int) (*ListPage, error)
+  ListVersions(bucket, prefix, keyMarker, versionIdMarker string, maxKeys int) (*VersionPage, error)
+  Head(bucket, key string) (*HeadInfo, error)
+
+  // Copy performs a same-backend server-side copy using the provider's
+  // native copy-source mechanism. It is only valid when source and target
+  // buckets live behind the same Backend instance.
+  Copy(sourceBucket, sourceKey, targetBucket, targetKey string, opts CopyOptions) error
+
+  // Get and Put together support cross-backend copies: Get streams an
+  // object from this backend, Put writes a stream of known size to it.
+  // Get only consults opts' CopySourceSSECustomerAlgorithm/Key/KeyMD5
+  // fields, the customer key needed to read an SSE-C encrypted object.
+  Get(bucket, key string, opts CopyOptions) (io.ReadCloser, int64, error)
+  Put(bucket, key string, body io.Reader, size int64, opts CopyOptions) error
+
+  Delete(bucket, key string) error
+}
+
+// MetadataSearcher is an optional capability a Backend may implement for
+// providers that support a richer query language than plain prefix listing,
+// e.g. ECS's metadata search. Callers should type-assert for it rather than
+// adding it to Backend, since most providers don't have an equivalent.
+type MetadataSearcher interface {
+  Query(bucket, query, marker string, maxKeys int) (*ListPage, error)
+}
+
+// CredentialRefresher is an optional capability a Backend may implement to
+// pick up rotated credentials without reconnecting. Callers should
+// type-assert for it and treat its absence as "this backend's credentials
+// don't change for the life of the process".
+type CredentialRefresher interface {
+  Refresh(accessKey, secretKey, sessionToken string) error
+}
@@ -0,0 +1,147 @@
+// Package ecs adapts the existing goamz-derived s3 client to the backends.Backend
+// interface, so ECS can be used as either the source or the target of a copy.
+package ecs
+
+import (
+  "io"
+  "sync"
+
+  "github.com/djannot/ecss3copy/backends"
+  "github.com/djannot/ecss3copy/s3"
+  "github.com/mitchellh/goamz/aws"
+)
+
+// Backend is a backends.Backend implemented against an ECS S3 endpoint.
+// client is protected by mu so a credential Refresh can swap it out while
+// other goroutines are using the backend.
+type Backend struct {
+  mu sync.RWMutex
+  client *s3.S3
+  endpoint string
+}
+
+// New creates an ECS-backed Backend for the given endpoint and credentials.
+func New(endpoint, accessKey, secretKey string) *Backend {
+  return &Backend{
+    client: newClient(endpoint, accessKey, secretKey, ""),
+    endpoint: endpoint,
+  }
+}
+
+func newClient(endpoint, accessKey, secretKey, sessionToken string) *s3.S3 {
+  auth := aws.Auth{AccessKey: accessKey, SecretKey: secretKey, Token: sessionToken}
+  region := aws.Region{Name: "Special", S3Endpoint: endpoint}
+  return s3.New(auth, region)
+}
+
+// Refresh rebuilds the underlying client with new credentials, so rotated
+// keys (e.g. from a Kubernetes Secret) take effect without reconnecting.
+func (b *Backend) Refresh(accessKey, secretKey, sessionToken string) error {
+  client := newClient(b.endpoint, accessKey, secretKey, sessionToken)
+  b.mu.Lock()
+  b.client = client
+  b.mu.Unlock()
+  return nil
+}
+
+func (b *Backend) activeClient() *s3.S3 {
+  b.mu.RLock()
+  defer b.mu.RUnlock()
+  return b.client
+}
+
+func (b *Backend) List(bucket, prefix, marker string, maxKeys int) (*backends.ListPage, error) {
+  resp, err := b.activeClient().Bucket(bucket).List(prefix, "", marker, maxKeys)
+  if err != nil {
+    return nil, err
+  }
+  page := &backends.ListPage{IsTruncated: resp.IsTruncated}
+  for _, key := range resp.Contents {
+    page.Objects = append(page.Objects, backends.Object{Key: key.Key, Size: key.Size, ETag: key.ETag, LastModified: key.LastModified})
+  }
+  if len(page.Objects) > 0 {
+    page.NextMarker = page.Objects[len(page.Objects)-1].Key
+  }
+  return page, nil
+}
+
+func (b *Backend) ListVersions(bucket, prefix, keyMarker, versionIdMarker string, maxKeys int) (*backends.VersionPage, error) {
+  resp, err := b.activeClient().Bucket(bucket).Versions(prefix, keyMarker, versionIdMarker, maxKeys)
+  if err != nil {
+    return nil, err
+  }
+  page := &backends.VersionPage{
+    IsTruncated: resp.IsTruncated,
+    NextKeyMarker: resp.NextKeyMarker,
+    NextVersionIdMarker: resp.NextVersionIdMarker,
+  }
+  for _, v := range resp.Versions {
+    page.Objects = append(page.Objects, backends.Object{Key: v.Key.Key, Size: v.Size, ETag: v.ETag, VersionId: v.VersionId})
+  }
+  for _, d := range resp.DeleteMarkers {
+    page.Objects = append(page.Objects, backends.Object{Key: d.Key.Key, VersionId: d.VersionId, IsDeleteMarker: true})
+  }
+  return page, nil
+}
+
+func (b *Backend) Head(bucket, key string) (*backends.HeadInfo, error) {
+  resp, err := b.activeClient().Bucket(bucket).Head(key)
+  if err != nil {
+    return nil, err
+  }
+  return &backends.HeadInfo{
+    ServerSideEncryption: resp.ServerSideEncryption,
+    SSEKMSKeyId: resp.SSEKMSKeyId,
+    ContentLength: resp.ContentLength,
+    ETag: resp.ETag,
+  }, nil
+}
+
+func (b *Backend) Copy(sourceBucket, sourceKey, targetBucket, targetKey string, opts backends.CopyOptions) error {
+  return b.activeClient().Bucket(targetBucket).CopyToNewBucket(sourceKey, targetKey, sourceBucket, toS3CopyOptions(opts))
+}
+
+func (b *Backend) Get(bucket, key string, opts backends.CopyOptions) (io.ReadCloser, int64, error) {
+  return b.activeClient().Bucket(bucket).GetReader(key, opts.CopySourceSSECustomerAlgorithm, opts.CopySourceSSECustomerKey, opts.CopySourceSSECustomerKeyMD5)
+}
+
+func (b *Backend) Put(bucket, key string, body io.Reader, size int64, opts backends.CopyOptions) error {
+  return b.activeClient().Bucket(bucket).PutReader(key, body, size, toS3CopyOptions(opts))
+}
+
+func (b *Backend) Delete(bucket, key string) error {
+  return b.activeClient().Bucket(bucket).Del(key)
+}
+
+// Query runs an ECS metadata search query, satisfying backends.MetadataSearcher.
+func (b *Backend) Query(bucket, query, marker string, maxKeys int) (*backends.ListPage, error) {
+  resp, err := b.activeClient().Bucket(bucket).Query(query, marker, maxKeys)
+  if err != nil {
+    return nil, err
+  }
+  page := &backends.ListPage{}
+  for _, item := range resp.EntryLists {
+    page.Objects = append(page.Objects, backends.Object{Key: item.ObjectName})
+  }
+  if resp.NextMarker != "NO MORE PAGES" {
+    page.IsTruncated = true
+    page.NextMarker = resp.NextMarker
+  }
+  return page, nil
+}
+
+func toS3CopyOptions(opts backends.CopyOptions) s3.CopyOptions {
+  return s3.CopyOptions{
+    ACL: s3.ACL(opts.ACL),
+    MetadataDirective: opts.MetadataDirective,
+    SourceVersionId: opts.SourceVersionId,
+    ServerSideEncryption: opts.ServerSideEncryption,
+    SSEKMSKeyId: opts.SSEKMSKeyId,
+    SSECustomerAlgorithm: opts.SSECustomerAlgorithm,
+    SSECustomerKey: opts.SSECustomerKey,
+    SSECustomerKeyMD5: opts.SSECustomerKeyMD5,
+    CopySourceSSECustomerAlgorithm: opts.CopySourceSSECustomerAlgorithm,
+    CopySourceSSECustomerKey: opts.CopySourceSSECustomerKey,
+    CopySourceSSECustomerKeyMD5: opts.CopySourceSSECustomerKeyMD5,
+  }
+}
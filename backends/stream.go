@@ -0,0 +1,15 @@
+package backends
+
+// CopyStream copies a single object between two different Backend
+// instances (e.g. ECS source, AWS target) by streaming it through the
+// process: Get from src, Put to dst. It's the fallback used whenever src
+// and dst aren't the same backend instance, since the provider-native copy
+// (Backend.Copy) only works within one backend.
+func CopyStream(src, dst Backend, sourceBucket, sourceKey, targetBucket, targetKey string, opts CopyOptions) error {
+  body, size, err := src.Get(sourceBucket, sourceKey, opts)
+  if err != nil {
+    return err
+  }
+  defer body.Close()
+  return dst.Put(targetBucket, targetKey, body, size, opts)
+}
@@ -0,0 +1,53 @@
+// Package credentials resolves source/target access keys from a chain of
+// providers, so static keys never have to live in a shell history or a
+// process listing: explicit CLI flags first, then environment variables,
+// then a credentials file, then a Kubernetes Secret.
+package credentials
+
+import "fmt"
+
+// Pair is a single access/secret/session-token triple.
+type Pair struct {
+  AccessKey string
+  SecretKey string
+  SessionToken string
+}
+
+// Set is the resolved credentials (and optional endpoint/region overrides)
+// for both sides of a copy.
+type Set struct {
+  Source Pair
+  Target Pair
+  // SourceEndpoint/TargetEndpoint are optional overrides a provider can
+  // supply (e.g. a Kubernetes Secret carrying the ECS endpoint alongside its
+  // keys). Empty means "use whatever the CLI flags already said". A Set
+  // carries no region override; regions come only from the CLI flags.
+  SourceEndpoint string
+  TargetEndpoint string
+}
+
+// Provider resolves a Set, or reports that it has nothing to contribute by
+// returning an error.
+type Provider interface {
+  Resolve() (*Set, error)
+}
+
+// Chain tries each Provider in order and returns the first one that
+// resolves successfully.
+type Chain struct {
+  Providers []Provider
+}
+
+func (c Chain) Resolve() (*Set, error) {
+  for _, p := range c.Providers {
+    set, err := p.Resolve()
+    if err == nil {
+      return set, nil
+    }
+  }
+  return nil, fmt.Errorf("credentials: no provider in the chain resolved")
+}
+
+func (p Pair) empty() bool {
+  return p.AccessKey == "" && p.SecretKey == ""
+}
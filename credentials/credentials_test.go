@@ -0,0 +1,49 @@
+package credentials
+
+import (
+  "errors"
+  "testing"
+)
+
+type fakeProvider struct {
+  set *Set
+  err error
+}
+
+func (f fakeProvider) Resolve() (*Set, error) {
+  return f.set, f.err
+}
+
+func TestChainResolveUsesFirstSuccessfulProvider(t *testing.T) {
+  want := &Set{Source: Pair{AccessKey: "from-second"}}
+  chain := Chain{Providers: []Provider{
+    fakeProvider{err: errors.New("flags: nothing set")},
+    fakeProvider{set: want},
+    fakeProvider{set: &Set{Source: Pair{AccessKey: "from-third"}}},
+  }}
+
+  got, err := chain.Resolve()
+  if err != nil {
+    t.Fatalf("Resolve() error = %v, want nil", err)
+  }
+  if got != want {
+    t.Fatalf("Resolve() = %v, want the second provider's Set", got)
+  }
+}
+
+func TestChainResolveErrorsWhenNoProviderResolves(t *testing.T) {
+  chain := Chain{Providers: []Provider{
+    fakeProvider{err: errors.New("flags: nothing set")},
+    fakeProvider{err: errors.New("env: nothing set")},
+  }}
+
+  if _, err := chain.Resolve(); err == nil {
+    t.Fatal("Resolve() error = nil, want an error")
+  }
+}
+
+func TestChainResolveEmptyChain(t *testing.T) {
+  if _, err := (Chain{}).Resolve(); err == nil {
+    t.Fatal("Resolve() error = nil, want an error")
+  }
+}
@@ -0,0 +1,25 @@
+package credentials
+
+import (
+  "fmt"
+  "os"
+)
+
+// EnvProvider resolves credentials from the standard AWS environment
+// variables. The same pair is used for both source and target, since
+// there's only one set of well-known variable names.
+type EnvProvider struct{}
+
+func (EnvProvider) Resolve() (*Set, error) {
+  accessKey := os.Getenv("AWS_ACCESS_KEY_ID")
+  secretKey := os.Getenv("AWS_SECRET_ACCESS_KEY")
+  if accessKey == "" || secretKey == "" {
+    return nil, fmt.Errorf("credentials: AWS_ACCESS_KEY_ID/AWS_SECRET_ACCESS_KEY not set")
+  }
+  pair := Pair{
+    AccessKey: accessKey,
+    SecretKey: secretKey,
+    SessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+  }
+  return &Set{Source: pair, Target: pair}, nil
+}
@@ -0,0 +1,78 @@
+package credentials
+
+import (
+  "bufio"
+  "fmt"
+  "os"
+  "strings"
+)
+
+// FileProvider resolves credentials from an INI-style file with a [source]
+// and a [target] section, each holding access-key/secret-key/session-token
+// entries, e.g.:
+//
+//   [source]
+//   access-key = AKIA...
+//   secret-key = ...
+//
+//   [target]
+//   access-key = AKIA...
+//   secret-key = ...
+type FileProvider struct {
+  Path string
+}
+
+func (f FileProvider) Resolve() (*Set, error) {
+  file, err := os.Open(f.Path)
+  if err != nil {
+    return nil, fmt.Errorf("credentials: %w", err)
+  }
+  defer file.Close()
+
+  sections := map[string]map[string]string{}
+  section := ""
+  scanner := bufio.NewScanner(file)
+  for scanner.Scan() {
+    line := strings.TrimSpace(scanner.Text())
+    if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+      continue
+    }
+    if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+      section = strings.TrimSpace(line[1 : len(line)-1])
+      sections[section] = map[string]string{}
+      continue
+    }
+    parts := strings.SplitN(line, "=", 2)
+    if len(parts) != 2 || section == "" {
+      continue
+    }
+    sections[section][strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+  }
+  if err := scanner.Err(); err != nil {
+    return nil, fmt.Errorf("credentials: %w", err)
+  }
+
+  source, ok := sections["source"]
+  if !ok {
+    return nil, fmt.Errorf("credentials: %s has no [source] section", f.Path)
+  }
+  target, ok := sections["target"]
+  if !ok {
+    return nil, fmt.Errorf("credentials: %s has no [target] section", f.Path)
+  }
+
+  return &Set{
+    Source: pairFromSection(source),
+    Target: pairFromSection(target),
+    SourceEndpoint: source["endpoint"],
+    TargetEndpoint: target["endpoint"],
+  }, nil
+}
+
+func pairFromSection(section map[string]string) Pair {
+  return Pair{
+    AccessKey: section["access-key"],
+    SecretKey: section["secret-key"],
+    SessionToken: section["session-token"],
+  }
+}
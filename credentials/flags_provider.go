@@ -0,0 +1,21 @@
+package credentials
+
+import "fmt"
+
+// FlagsProvider resolves credentials passed explicitly on the command line
+// (the tool's original behavior). It's first in the chain since an explicit
+// flag should always win.
+type FlagsProvider struct {
+  SourceAccessKey, SourceSecretKey string
+  TargetAccessKey, TargetSecretKey string
+}
+
+func (f FlagsProvider) Resolve() (*Set, error) {
+  if f.SourceAccessKey == "" && f.SourceSecretKey == "" {
+    return nil, fmt.Errorf("credentials: no --user/--password given")
+  }
+  return &Set{
+    Source: Pair{AccessKey: f.SourceAccessKey, SecretKey: f.SourceSecretKey},
+    Target: Pair{AccessKey: f.TargetAccessKey, SecretKey: f.TargetSecretKey},
+  }, nil
+}
@@ -0,0 +1,60 @@
+package credentials
+
+import (
+  "context"
+  "fmt"
+  "strings"
+
+  "k8s.io/client-go/kubernetes"
+  "k8s.io/client-go/rest"
+  metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SecretProvider resolves credentials from a Kubernetes Secret, read via the
+// in-cluster client-go config. NameNamespace is "name/namespace" as passed
+// to --credentials-secret.
+type SecretProvider struct {
+  NameNamespace string
+}
+
+func (s SecretProvider) Resolve() (*Set, error) {
+  name, namespace, err := splitNameNamespace(s.NameNamespace)
+  if err != nil {
+    return nil, err
+  }
+
+  config, err := rest.InClusterConfig()
+  if err != nil {
+    return nil, fmt.Errorf("credentials: not running in-cluster: %w", err)
+  }
+  clientset, err := kubernetes.NewForConfig(config)
+  if err != nil {
+    return nil, fmt.Errorf("credentials: %w", err)
+  }
+
+  secret, err := clientset.CoreV1().Secrets(namespace).Get(context.Background(), name, metav1.GetOptions{})
+  if err != nil {
+    return nil, fmt.Errorf("credentials: fetching secret %s/%s: %w", namespace, name, err)
+  }
+
+  data := map[string]string{}
+  for k, v := range secret.Data {
+    data[k] = string(v)
+  }
+
+  sessionToken := data["session-token"]
+  return &Set{
+    Source: Pair{AccessKey: data["source-access-key"], SecretKey: data["source-secret-key"], SessionToken: sessionToken},
+    Target: Pair{AccessKey: data["target-access-key"], SecretKey: data["target-secret-key"], SessionToken: sessionToken},
+    SourceEndpoint: data["endpoint"],
+    TargetEndpoint: data["endpoint"],
+  }, nil
+}
+
+func splitNameNamespace(nameNamespace string) (name, namespace string, err error) {
+  parts := strings.SplitN(nameNamespace, "/", 2)
+  if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+    return "", "", fmt.Errorf("credentials: --credentials-secret must be name/namespace, got %q", nameNamespace)
+  }
+  return parts[0], parts[1], nil
+}
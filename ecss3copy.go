@@ -1,27 +1,91 @@
 package main
 
 import (
+  "bufio"
+  "context"
+  "encoding/base64"
+  "encoding/csv"
+  "encoding/json"
+  "crypto/md5"
   "log"
+  "math/rand"
   "os"
+  "strings"
   "sync"
   "sync/atomic"
   "time"
-  "github.com/djannot/ecss3copy/s3"
+  "github.com/djannot/ecss3copy/backends"
+  "github.com/djannot/ecss3copy/backends/awsv4"
+  "github.com/djannot/ecss3copy/backends/ecs"
+  "github.com/djannot/ecss3copy/credentials"
+  "github.com/djannot/ecss3copy/state"
   "github.com/jessevdk/go-flags"
-  "github.com/mitchellh/goamz/aws"
+  "golang.org/x/time/rate"
 )
 
 const retries = 3
-var c = make(chan KeysToSend)
-var s3Client *s3.S3
+var sourceBackend backends.Backend
+var targetBackend backends.Backend
+var credentialChain credentials.Chain
+var stateStore *state.Store
+var onlyFrom map[string]bool
 var ops uint64 = 0
 var succeeded uint64 = 0
 var failed uint64 = 0
 
-type KeysToSend struct {
-  Keys []s3.Key
+// workItem is a single unit of work handed to the worker pool: one object
+// plus the operation to perform on it.
+type workItem struct {
+  Object backends.Object
   Operation string
-  Options interface{}
+  Options CopyBucketOptions
+}
+
+// pool is a bounded worker pool: a fixed number of goroutines pull items off
+// work and process them one at a time, optionally throttled by limiter. wg
+// tracks in-flight items so the caller can drain the pool once listing has
+// finished producing work, instead of waiting page by page.
+type pool struct {
+  work chan workItem
+  wg sync.WaitGroup
+  limiter *rate.Limiter
+}
+
+func newPool(concurrency int, ratePerSecond float64) *pool {
+  var limiter *rate.Limiter
+  if ratePerSecond > 0 {
+    limiter = rate.NewLimiter(rate.Limit(ratePerSecond), 1)
+  }
+  p := &pool{
+    work: make(chan workItem, concurrency),
+    limiter: limiter,
+  }
+  for i := 0; i < concurrency; i++ {
+    go p.run()
+  }
+  return p
+}
+
+func (p *pool) run() {
+  for item := range p.work {
+    if p.limiter != nil {
+      p.limiter.Wait(context.Background())
+    }
+    if item.Operation == "CopyObject" {
+      copyObject(item.Object, item.Options, "public-read", "REPLACE")
+    } else if item.Operation == "CopyVersion" {
+      copyVersion(item.Object, item.Options, "public-read", "REPLACE")
+    }
+    p.wg.Done()
+  }
+}
+
+// dispatch hands a single object to the pool. It blocks once the pool's
+// buffer is full, which is the backpressure that keeps a large listing from
+// being read entirely into memory ahead of the workers.
+func (p *pool) dispatch(object backends.Object, operation string, options CopyBucketOptions) {
+  p.wg.Add(1)
+  p.work <- workItem{Object: object, Operation: operation, Options: options}
 }
 
 type CopyBucketOptions struct {
@@ -30,12 +94,32 @@ type CopyBucketOptions struct {
   Query string
   SourcePrefix string
   TargetPrefix string
+  ServerSideEncryption string
+  SSEKMSKeyId string
+  SSECustomerAlgorithm string
+  SSECustomerKey string
+  SSECustomerKeyMD5 string
+  SourceSSECustomerAlgorithm string
+  SourceSSECustomerKey string
+  SourceSSECustomerKeyMD5 string
+  ReportEncryption bool
+  Versions bool
+  LatestOnly bool
+  // CrossBackend is true when the source and target differ in backend kind
+  // or endpoint, so copies must be streamed through the process
+  // (backends.CopyStream) instead of using a provider-native copy-source.
+  CrossBackend bool
+  MultipartThreshold int64
+  PartSize int64
+  // DryRun, when set, records what would be copied to the state file
+  // without issuing the copy.
+  DryRun bool
 }
 
 var opts struct {
     EndPoint string `short:"e" long:"endpoint" description:"The ECS endpoint" required:"true"`
-    ObjectUser string `short:"u" long:"user" description:"The ECS object user" required:"true"`
-    Password string `short:"p" long:"password" description:"The ECS object user password" required:"true"`
+    ObjectUser string `short:"u" long:"user" description:"The ECS object user (optional if credentials come from the environment, --credentials-file or --credentials-secret)"`
+    Password string `short:"p" long:"password" description:"The ECS object user password (optional, see --user)"`
     SourceBucket string `short:"s" long:"source" description:"The ECS source bucket" required:"true"`
     TargetBucket string `short:"t" long:"target" description:"The ECS target bucket" required:"true"`
     SourcePrefix string `short:"x" long:"sourceprefix" description:"The source prefix"`
@@ -43,6 +127,32 @@ var opts struct {
     MaxKeys int `short:"m" long:"maxkeys" description:"The number of keys to retrieve simultaneously from the ECS source bucket" default:"100"`
     MetadataSearchQuery string `short:"q" long:"query" description:"The ECS metadata search query to select the objects from the source bucket"`
     Verbose bool `short:"v" long:"verbose" description:"Verbose mode also display the object successfully copies"`
+    SSE string `long:"sse" description:"Server-side encryption to apply to copied objects: AES256 or aws:kms"`
+    SSEKMSKeyId string `long:"sse-kms-key-id" description:"The KMS key id to use when --sse is aws:kms"`
+    SSECKey string `long:"sse-c-key" description:"The base64-encoded customer encryption key to apply to the target object (SSE-C)"`
+    SourceSSECKey string `long:"source-sse-c-key" description:"The base64-encoded customer encryption key needed to read an SSE-C encrypted source object"`
+    ReportEncryption bool `long:"report-encryption" description:"Verbose mode that also logs the server-side encryption state of each copied object"`
+    Versions bool `long:"versions" description:"Copy every version of each object, replaying history onto the target bucket, instead of just the current version"`
+    LatestOnly bool `long:"latest-only" description:"With --versions, only copy the current version of each object (the default behavior without --versions)"`
+    Concurrency int `long:"concurrency" description:"The number of worker goroutines copying objects in parallel" default:"10"`
+    RateLimit float64 `long:"rate-limit" description:"Maximum copy operations per second across all workers (0 means unlimited)" default:"0"`
+    SourceBackend string `long:"source-backend" description:"Backend for the source: ecs or aws" default:"ecs"`
+    TargetBackend string `long:"target-backend" description:"Backend for the target: ecs or aws" default:"ecs"`
+    SourceEndpoint string `long:"source-endpoint" description:"Endpoint for the source backend (defaults to --endpoint)"`
+    TargetEndpoint string `long:"target-endpoint" description:"Endpoint for the target backend (defaults to --endpoint)"`
+    SourceRegion string `long:"source-region" description:"AWS region for the source backend, when --source-backend is aws"`
+    TargetRegion string `long:"target-region" description:"AWS region for the target backend, when --target-backend is aws"`
+    SourceProfile string `long:"source-profile" description:"AWS shared config profile for the source backend, when --source-backend is aws"`
+    TargetProfile string `long:"target-profile" description:"AWS shared config profile for the target backend, when --target-backend is aws"`
+    MultipartThreshold int64 `long:"multipart-threshold" description:"Object size in bytes at or above which a cross-backend copy to an aws target switches to multipart upload (an ecs target always uses a single PUT)" default:"104857600"`
+    PartSize int64 `long:"part-size" description:"Part size in bytes used for multipart uploads of cross-backend copies" default:"10485760"`
+    CredentialsFile string `long:"credentials-file" description:"Path to an INI-style file with [source] and [target] credential sections"`
+    CredentialsSecret string `long:"credentials-secret" description:"name/namespace of a Kubernetes Secret to read source/target credentials from, when running in-cluster"`
+    StateFile string `long:"state-file" description:"Path to a BoltDB file recording per-key copy progress, enabling delta sync and resumable runs"`
+    Resume bool `long:"resume" description:"Skip keys the state file already marks done with a matching ETag/size/last-modified, resuming an interrupted run (requires --state-file)"`
+    DryRun bool `long:"dry-run" description:"Populate the state file without copying anything, to preview a large migration (requires --state-file)"`
+    Report string `long:"report" description:"Write a JSON (or CSV, by extension) report of failed keys to this path after the run"`
+    OnlyFrom string `long:"only-from" description:"Only copy the keys from this file: a previous --report .json/.csv (its failed keys are extracted automatically), or a plain newline-separated key list"`
 }
 
 func main() {
@@ -51,17 +161,34 @@ func main() {
     os.Exit(1)
   }
 
-  s3Auth := aws.Auth{
-    AccessKey: opts.ObjectUser,
-    SecretKey: opts.Password,
+  if opts.StateFile != "" {
+    var err error
+    stateStore, err = state.Open(opts.StateFile)
+    if err != nil {
+      log.Fatal(err)
+    }
+    defer stateStore.Close()
+  }
+  if opts.OnlyFrom != "" {
+    onlyFrom = loadOnlyFrom(opts.OnlyFrom)
   }
 
-  s3SpecialRegion := aws.Region{
-    Name: "Special",
-    S3Endpoint: opts.EndPoint,
+  credentialChain = buildCredentialChain()
+  creds, err := credentialChain.Resolve()
+  if err != nil {
+    log.Fatal(err)
   }
 
-  s3Client = s3.New(s3Auth, s3SpecialRegion)
+  sourceEndpoint := endpointOrDefault(opts.SourceEndpoint, creds.SourceEndpoint)
+  targetEndpoint := endpointOrDefault(opts.TargetEndpoint, creds.TargetEndpoint)
+
+  sourceBackend = newBackend(opts.SourceBackend, sourceEndpoint, opts.SourceRegion, opts.SourceProfile, creds.Source)
+  targetBackend = newBackend(opts.TargetBackend, targetEndpoint, opts.TargetRegion, opts.TargetProfile, creds.Target)
+
+  crossBackend := opts.SourceBackend != opts.TargetBackend || sourceEndpoint != targetEndpoint
+
+  sseCAlgorithm, sseCKey, sseCKeyMD5 := sseCustomerHeaders(opts.SSECKey)
+  sourceSSECAlgorithm, sourceSSECKey, sourceSSECKeyMD5 := sseCustomerHeaders(opts.SourceSSECKey)
 
   copyBucketOptions := CopyBucketOptions{
     SourceBucket: opts.SourceBucket,
@@ -69,6 +196,21 @@ func main() {
     Query: opts.MetadataSearchQuery,
     SourcePrefix: opts.SourcePrefix,
     TargetPrefix: opts.TargetPrefix,
+    ServerSideEncryption: opts.SSE,
+    SSEKMSKeyId: opts.SSEKMSKeyId,
+    SSECustomerAlgorithm: sseCAlgorithm,
+    SSECustomerKey: sseCKey,
+    SSECustomerKeyMD5: sseCKeyMD5,
+    SourceSSECustomerAlgorithm: sourceSSECAlgorithm,
+    SourceSSECustomerKey: sourceSSECKey,
+    SourceSSECustomerKeyMD5: sourceSSECKeyMD5,
+    ReportEncryption: opts.ReportEncryption,
+    Versions: opts.Versions,
+    LatestOnly: opts.LatestOnly,
+    CrossBackend: crossBackend,
+    MultipartThreshold: opts.MultipartThreshold,
+    PartSize: opts.PartSize,
+    DryRun: opts.DryRun,
   }
   startTime := time.Now()
   copyBucket(copyBucketOptions)
@@ -77,121 +219,456 @@ func main() {
   log.Printf("%f operations per second", float64(ops) / duration.Seconds())
   log.Printf("%d operations succeeded", succeeded)
   log.Printf("%d operations failed", failed)
+
+  if opts.Report != "" {
+    if stateStore == nil {
+      log.Print("--report requires --state-file, skipping report")
+    } else if err := stateStore.WriteReport(opts.Report); err != nil {
+      log.Printf("could not write report to %s: %s", opts.Report, err)
+    }
+  }
 }
 
-func listObjects(wg *sync.WaitGroup, c chan KeysToSend, sourceBucket string, operation string, marker string, sourceprefix string, options interface{}) {
-  log.Println("Start listing")
-  s3Bucket := s3Client.Bucket(sourceBucket)
-  listResp, err := s3Bucket.List(sourceprefix, "", marker, opts.MaxKeys)
-  if(err != nil) {
+// loadOnlyFrom reads the set of keys to restrict this run to from path: a
+// --report .json or .csv file (so "rerun just the failures" works directly
+// against what --report produced), or otherwise a plain newline-separated
+// list of keys.
+func loadOnlyFrom(path string) map[string]bool {
+  switch {
+  case strings.HasSuffix(path, ".json"):
+    return loadOnlyFromJSON(path)
+  case strings.HasSuffix(path, ".csv"):
+    return loadOnlyFromCSV(path)
+  default:
+    return loadOnlyFromLines(path)
+  }
+}
+
+func loadOnlyFromJSON(path string) map[string]bool {
+  data, err := os.ReadFile(path)
+  if err != nil {
     log.Fatal(err)
   }
-  lastKey := ""
-  keys := []s3.Key{}
-  for _, key := range listResp.Contents {
-    lastKey = key.Key
-    keys = append(keys, key)
+  var records []state.Record
+  if err := json.Unmarshal(data, &records); err != nil {
+    log.Fatal(err)
+  }
+  keys := map[string]bool{}
+  for _, record := range records {
+    keys[record.Key] = true
+  }
+  return keys
+}
+
+func loadOnlyFromCSV(path string) map[string]bool {
+  file, err := os.Open(path)
+  if err != nil {
+    log.Fatal(err)
   }
+  defer file.Close()
 
-  if(len(keys) > 0) {
-    keysToSend := KeysToSend{
-      Keys: keys,
-      Operation: operation,
-      Options: options,
+  rows, err := csv.NewReader(file).ReadAll()
+  if err != nil {
+    log.Fatal(err)
+  }
+  keys := map[string]bool{}
+  if len(rows) > 0 {
+    rows = rows[1:]
+  }
+  for _, row := range rows {
+    if len(row) > 0 {
+      keys[row[0]] = true
     }
-    c <- keysToSend
   }
+  return keys
+}
 
-  wg.Wait()
+func loadOnlyFromLines(path string) map[string]bool {
+  file, err := os.Open(path)
+  if err != nil {
+    log.Fatal(err)
+  }
+  defer file.Close()
 
-  if(listResp.IsTruncated) {
-    listObjects(wg, c, sourceBucket, operation, lastKey, sourceprefix, options)
+  keys := map[string]bool{}
+  scanner := bufio.NewScanner(file)
+  for scanner.Scan() {
+    if key := scanner.Text(); key != "" {
+      keys[key] = true
+    }
+  }
+  if err := scanner.Err(); err != nil {
+    log.Fatal(err)
   }
+  return keys
 }
 
-func queryObjects(wg *sync.WaitGroup, c chan KeysToSend, sourceBucket string, query string, operation string, marker string, options interface{}) {
-  s3Bucket := s3Client.Bucket(sourceBucket)
-  queryResp, err := s3Bucket.Query(query, marker, opts.MaxKeys)
+// endpointOrDefault returns the first non-empty of the side-specific
+// --source/target-endpoint flag, an endpoint supplied by the credential
+// provider (e.g. a Kubernetes Secret), or the shared --endpoint flag.
+func endpointOrDefault(flagEndpoint, credentialEndpoint string) string {
+  if flagEndpoint != "" {
+    return flagEndpoint
+  }
+  if credentialEndpoint != "" {
+    return credentialEndpoint
+  }
+  return opts.EndPoint
+}
 
+// newBackend builds the Backend for one side of the copy. kind is "ecs" or
+// "aws"; region/profile are only meaningful for "aws". For "ecs", creds is
+// used directly; for "aws" creds is not used, since the SDK resolves its own
+// credential chain.
+func newBackend(kind, endpoint, region, profile string, creds credentials.Pair) backends.Backend {
+  if kind == "aws" {
+    backend, err := awsv4.New(context.Background(), region, profile, endpoint)
+    if err != nil {
+      log.Fatal(err)
+    }
+    return backend
+  }
+  return ecs.New(endpoint, creds.AccessKey, creds.SecretKey)
+}
+
+// buildCredentialChain assembles the credential provider chain in priority
+// order: explicit flags, then environment variables, then --credentials-file,
+// then --credentials-secret.
+func buildCredentialChain() credentials.Chain {
+  chain := credentials.Chain{}
+  if opts.ObjectUser != "" || opts.Password != "" {
+    chain.Providers = append(chain.Providers, credentials.FlagsProvider{
+      SourceAccessKey: opts.ObjectUser,
+      SourceSecretKey: opts.Password,
+      TargetAccessKey: opts.ObjectUser,
+      TargetSecretKey: opts.Password,
+    })
+  }
+  chain.Providers = append(chain.Providers, credentials.EnvProvider{})
+  if opts.CredentialsFile != "" {
+    chain.Providers = append(chain.Providers, credentials.FileProvider{Path: opts.CredentialsFile})
+  }
+  if opts.CredentialsSecret != "" {
+    chain.Providers = append(chain.Providers, credentials.SecretProvider{NameNamespace: opts.CredentialsSecret})
+  }
+  return chain
+}
+
+// refreshCredentials re-resolves the credential chain and, for any backend
+// that supports it, swaps in the new keys. It's called at each listing
+// pagination boundary so a rotated Kubernetes Secret takes effect mid-run
+// without restarting the tool.
+func refreshCredentials() {
+  creds, err := credentialChain.Resolve()
+  if err != nil {
+    log.Printf("credentials: could not refresh, keeping existing credentials: %s", err)
+    return
+  }
+  if refresher, ok := sourceBackend.(backends.CredentialRefresher); ok {
+    if err := refresher.Refresh(creds.Source.AccessKey, creds.Source.SecretKey, creds.Source.SessionToken); err != nil {
+      log.Printf("credentials: could not refresh source backend: %s", err)
+    }
+  }
+  if refresher, ok := targetBackend.(backends.CredentialRefresher); ok {
+    if err := refresher.Refresh(creds.Target.AccessKey, creds.Target.SecretKey, creds.Target.SessionToken); err != nil {
+      log.Printf("credentials: could not refresh target backend: %s", err)
+    }
+  }
+}
+
+func listObjects(p *pool, sourceBucket string, operation string, marker string, sourceprefix string, options CopyBucketOptions) {
+  log.Println("Start listing")
+  refreshCredentials()
+  listResp, err := sourceBackend.List(sourceBucket, sourceprefix, marker, opts.MaxKeys)
   if(err != nil) {
     log.Fatal(err)
   }
+  lastKey := ""
+  for _, object := range listResp.Objects {
+    lastKey = object.Key
+    if shouldSkip(object) {
+      continue
+    }
+    p.dispatch(object, operation, options)
+  }
 
-  keys := []s3.Key{}
-  for _, item := range queryResp.EntryLists {
-    key := s3.Key{
-      Key: item.ObjectName,
+  if(listResp.IsTruncated) {
+    listObjects(p, sourceBucket, operation, lastKey, sourceprefix, options)
+  }
+}
+
+// shouldSkip reports whether object should be left out of this run: either
+// --only-from was given and object isn't in it, or --resume was given and the
+// state file already has its key+version recorded Done with a matching
+// ETag/size/last-modified.
+func shouldSkip(object backends.Object) bool {
+  if onlyFrom != nil && !onlyFrom[object.Key] {
+    return true
+  }
+  if stateStore != nil && opts.Resume {
+    record, found, err := stateStore.Get(object.Key, object.VersionId)
+    if err != nil {
+      log.Printf("state: could not look up %s, not skipping: %s", object.Key, err)
+      return false
+    }
+    if found && record.UpToDate(object.Size, object.ETag, object.LastModified) {
+      return true
     }
-    keys = append(keys, key)
-    wg.Add(1)
   }
+  return false
+}
 
-  if(len(keys) > 0) {
-    //wg.Add(1)
-    keysToSend := KeysToSend{
-      Keys: keys,
-      Operation: operation,
-      Options: options,
+func queryObjects(p *pool, sourceBucket string, query string, operation string, marker string, options CopyBucketOptions) {
+  refreshCredentials()
+  searcher, ok := sourceBackend.(backends.MetadataSearcher)
+  if !ok {
+    log.Fatalf("--query is only supported against an ECS source backend")
+  }
+  queryResp, err := searcher.Query(sourceBucket, query, marker, opts.MaxKeys)
+  if(err != nil) {
+    log.Fatal(err)
+  }
+
+  for _, object := range queryResp.Objects {
+    if shouldSkip(object) {
+      continue
     }
-    c <- keysToSend
+    p.dispatch(object, operation, options)
   }
 
-  wg.Wait()
-  if(queryResp.NextMarker != "NO MORE PAGES") {
-    queryObjects(wg, c, sourceBucket, query, operation, queryResp.NextMarker, options)
+  if(queryResp.IsTruncated) {
+    queryObjects(p, sourceBucket, query, operation, queryResp.NextMarker, options)
   }
 }
 
 func copyBucket(copyBucketOptions CopyBucketOptions) {
-  c := make(chan KeysToSend)
-  var wg sync.WaitGroup
+  versionReplay := copyBucketOptions.Versions && !copyBucketOptions.LatestOnly
 
-  go bucketWorker(&wg, c)
-  if copyBucketOptions.Query == "" {
-    listObjects(&wg, c,  copyBucketOptions.SourceBucket, "CopyObject", "", copyBucketOptions.SourcePrefix, copyBucketOptions)
+  concurrency := effectiveConcurrency(versionReplay, opts.Concurrency)
+  if concurrency != opts.Concurrency {
+    log.Printf("--versions replays history in order, forcing --concurrency to 1 (was %d)", opts.Concurrency)
+  }
+  p := newPool(concurrency, opts.RateLimit)
+
+  if versionReplay {
+    listObjectVersions(p, copyBucketOptions.SourceBucket, "CopyVersion", "", "", copyBucketOptions.SourcePrefix, copyBucketOptions)
+  } else if copyBucketOptions.Query == "" {
+    listObjects(p, copyBucketOptions.SourceBucket, "CopyObject", "", copyBucketOptions.SourcePrefix, copyBucketOptions)
   } else {
-    queryObjects(&wg, c,  copyBucketOptions.SourceBucket, copyBucketOptions.Query, "CopyObject", "", copyBucketOptions)
+    queryObjects(p, copyBucketOptions.SourceBucket, copyBucketOptions.Query, "CopyObject", "", copyBucketOptions)
   }
+
+  close(p.work)
+  p.wg.Wait()
 }
 
-func bucketWorker(wg *sync.WaitGroup, c chan KeysToSend) {
-  for {
-    keysToSend := <- c
-    for _, key := range keysToSend.Keys {
-      if(keysToSend.Operation == "CopyObject") {
-        go copyObject(wg, key, keysToSend.Options.(CopyBucketOptions), s3.PublicRead, "REPLACE")
+// effectiveConcurrency forces a single worker when versionReplay is set.
+// Replaying a key's full version history only lands in chronological order
+// if one version finishes on the target before the next starts; with more
+// than one worker, two versions of the same key would race each other and
+// whichever network call happens to finish last becomes "current",
+// regardless of dispatch order.
+func effectiveConcurrency(versionReplay bool, concurrency int) int {
+  if versionReplay {
+    return 1
+  }
+  return concurrency
+}
+
+// listObjectVersions walks every version of every object under sourceprefix,
+// oldest first, plus any delete markers, so the full history of the bucket
+// can be replayed onto the target. ListObjectVersions pages come back
+// newest-first overall (not just within a page), so the next page is fetched
+// and fully dispatched *before* this page's own (reversed) objects are
+// dispatched - otherwise a key whose history spans a pagination boundary
+// would have its older versions replayed after its newer ones.
+func listObjectVersions(p *pool, sourceBucket string, operation string, keyMarker string, versionIdMarker string, sourceprefix string, options CopyBucketOptions) {
+  log.Println("Start listing versions")
+  refreshCredentials()
+  versionsResp, err := sourceBackend.ListVersions(sourceBucket, sourceprefix, keyMarker, versionIdMarker, opts.MaxKeys)
+  if(err != nil) {
+    log.Fatal(err)
+  }
+
+  objects := reverseObjects(versionsResp.Objects)
+
+  if(versionsResp.IsTruncated) {
+    listObjectVersions(p, sourceBucket, operation, versionsResp.NextKeyMarker, versionsResp.NextVersionIdMarker, sourceprefix, options)
+  }
+
+  for _, object := range objects {
+    if shouldSkip(object) {
+      continue
+    }
+    p.dispatch(object, operation, options)
+  }
+}
+
+// reverseObjects reverses objects in place (oldest first instead of the
+// newest-first order ListObjectVersions returns a page in) and returns it.
+func reverseObjects(objects []backends.Object) []backends.Object {
+  for i, j := 0, len(objects)-1; i < j; i, j = i+1, j-1 {
+    objects[i], objects[j] = objects[j], objects[i]
+  }
+  return objects
+}
+
+// copyVersion replays a single historical version of an object onto the
+// target bucket. Delete markers are replayed as a DELETE on the target
+// rather than a copy, so the target's version history ends up matching
+// the source's.
+func copyVersion(object backends.Object, copyBucketOptions CopyBucketOptions, perm string, directive string) {
+  if object.IsDeleteMarker {
+    atomic.AddUint64(&ops, 1)
+    if copyBucketOptions.DryRun {
+      if opts.Verbose {
+        log.Printf("Delete marker for %s (version %s) would be replayed to %s", object.Key, object.VersionId, copyBucketOptions.TargetBucket)
       }
+      putState(object, state.Pending, 0, "")
+      atomic.AddUint64(&succeeded, 1)
+      return
     }
+    targetKey := copyBucketOptions.TargetPrefix + object.Key
+    if err := targetBackend.Delete(copyBucketOptions.TargetBucket, targetKey); err != nil {
+      atomic.AddUint64(&failed, 1)
+      log.Printf("Delete marker for %s could not be replayed to %s: %s", object.Key, copyBucketOptions.TargetBucket, err)
+      putState(object, state.Failed, 1, err.Error())
+    } else {
+      atomic.AddUint64(&succeeded, 1)
+      if opts.Verbose {
+        log.Printf("Delete marker for %s (version %s) has been replayed to %s", object.Key, object.VersionId, copyBucketOptions.TargetBucket)
+      }
+      putState(object, state.Done, 1, "")
+    }
+    return
   }
+  copyObject(object, copyBucketOptions, perm, directive)
 }
 
-func copyObject(wg *sync.WaitGroup, key s3.Key, copyBucketOptions CopyBucketOptions, perm s3.ACL, directive string) {
-  s3Bucket := s3Client.Bucket(copyBucketOptions.TargetBucket)
-  /*
-  Could be implemented to delete the objects in the source bucket
-  err := s3Bucket.Del(key.Key)
-  if(err != nil) {
-    log.Print(err)
+func copyOptionsFor(object backends.Object, copyBucketOptions CopyBucketOptions, perm string, directive string) backends.CopyOptions {
+  return backends.CopyOptions{
+    ACL: perm,
+    MetadataDirective: directive,
+    SourceVersionId: object.VersionId,
+    ServerSideEncryption: copyBucketOptions.ServerSideEncryption,
+    SSEKMSKeyId: copyBucketOptions.SSEKMSKeyId,
+    SSECustomerAlgorithm: copyBucketOptions.SSECustomerAlgorithm,
+    SSECustomerKey: copyBucketOptions.SSECustomerKey,
+    SSECustomerKeyMD5: copyBucketOptions.SSECustomerKeyMD5,
+    CopySourceSSECustomerAlgorithm: copyBucketOptions.SourceSSECustomerAlgorithm,
+    CopySourceSSECustomerKey: copyBucketOptions.SourceSSECustomerKey,
+    CopySourceSSECustomerKeyMD5: copyBucketOptions.SourceSSECustomerKeyMD5,
+    MultipartThreshold: copyBucketOptions.MultipartThreshold,
+    PartSize: copyBucketOptions.PartSize,
   }
-  */
+}
+
+func copyObject(object backends.Object, copyBucketOptions CopyBucketOptions, perm string, directive string) {
+  targetKey := copyBucketOptions.TargetPrefix + object.Key
   atomic.AddUint64(&ops, 1)
+
+  if copyBucketOptions.DryRun {
+    if opts.Verbose {
+      log.Printf("Object %s would be copied from %s to %s", object.Key, copyBucketOptions.SourceBucket, copyBucketOptions.TargetBucket)
+    }
+    putState(object, state.Pending, 0, "")
+    atomic.AddUint64(&succeeded, 1)
+    return
+  }
+
   tried := 0
   for {
-    err := s3Bucket.CopyToNewBucket(key.Key, copyBucketOptions.TargetPrefix + key.Key, copyBucketOptions.SourceBucket, perm, directive)
+    var err error
+    copyOpts := copyOptionsFor(object, copyBucketOptions, perm, directive)
+    if copyBucketOptions.CrossBackend {
+      err = backends.CopyStream(sourceBackend, targetBackend, copyBucketOptions.SourceBucket, object.Key, copyBucketOptions.TargetBucket, targetKey, copyOpts)
+    } else {
+      err = targetBackend.Copy(copyBucketOptions.SourceBucket, object.Key, copyBucketOptions.TargetBucket, targetKey, copyOpts)
+    }
     if(err != nil) {
       log.Print(err)
       tried++
-    } else {
-      atomic.AddUint64(&succeeded, 1)
-      if opts.Verbose {
-        log.Printf("Object %s has been copied from %s to %s", key.Key, copyBucketOptions.SourceBucket, copyBucketOptions.TargetBucket)
+      if tried >= retries {
+        atomic.AddUint64(&failed, 1)
+        log.Printf("Object %s hasn't been copied from %s to %s", object.Key, copyBucketOptions.SourceBucket, copyBucketOptions.TargetBucket)
+        putState(object, state.Failed, tried, err.Error())
+        break
       }
-      break
+      time.Sleep(backoffWithJitter(tried))
+      continue
     }
-    if tried >= retries {
-      atomic.AddUint64(&failed, 1)
-      log.Printf("Object %s hasn't been copied from %s to %s", key.Key, copyBucketOptions.SourceBucket, copyBucketOptions.TargetBucket)
+    atomic.AddUint64(&succeeded, 1)
+    if opts.Verbose {
+      log.Printf("Object %s has been copied from %s to %s", object.Key, copyBucketOptions.SourceBucket, copyBucketOptions.TargetBucket)
+    }
+    if copyBucketOptions.ReportEncryption {
+      reportEncryption(copyBucketOptions.TargetBucket, targetKey)
     }
+    putState(object, state.Done, tried+1, "")
+    break
+  }
+}
+
+// putState records the outcome of a copy attempt in the state file, if one
+// was configured. It's a no-op otherwise, so callers don't need to guard
+// every call site on stateStore being non-nil.
+func putState(object backends.Object, status state.Status, attempts int, errMsg string) {
+  if stateStore == nil {
+    return
+  }
+  record := state.Record{
+    Key: object.Key,
+    LastModified: object.LastModified,
+    Size: object.Size,
+    ETag: object.ETag,
+    SourceVersionId: object.VersionId,
+    Status: status,
+    Attempts: attempts,
+    Error: errMsg,
+  }
+  if err := stateStore.Put(record); err != nil {
+    log.Printf("state: could not record %s: %s", object.Key, err)
+  }
+}
+
+// backoffWithJitter returns the delay to wait before retry attempt n
+// (1-indexed), doubling a 100ms base each attempt and adding up to 50% jitter
+// so that many workers retrying at once don't all hammer the endpoint in
+// lockstep.
+func backoffWithJitter(attempt int) time.Duration {
+  base := 100 * time.Millisecond * time.Duration(1<<uint(attempt-1))
+  jitter := time.Duration(rand.Int63n(int64(base) / 2 + 1))
+  return base + jitter
+}
+
+// reportEncryption looks up the server-side encryption state of the object
+// that was just copied and logs it, mirroring how HeadObjectOutput's
+// ServerSideEncryption field is inspected when using the AWS SDK directly.
+func reportEncryption(targetBucket string, targetKey string) {
+  head, err := targetBackend.Head(targetBucket, targetKey)
+  if(err != nil) {
+    log.Printf("Object %s: could not read encryption state: %s", targetKey, err)
+    return
+  }
+  if head.ServerSideEncryption == "" {
+    log.Printf("Object %s is not server-side encrypted", targetKey)
+  } else if head.ServerSideEncryption == "aws:kms" {
+    log.Printf("Object %s is encrypted with %s (key %s)", targetKey, head.ServerSideEncryption, head.SSEKMSKeyId)
+  } else {
+    log.Printf("Object %s is encrypted with %s", targetKey, head.ServerSideEncryption)
+  }
+}
+
+// sseCustomerHeaders derives the SSE-C algorithm and key-MD5 headers from a
+// base64-encoded customer key. It returns empty strings when key is empty.
+func sseCustomerHeaders(base64Key string) (algorithm, key, keyMD5 string) {
+  if base64Key == "" {
+    return "", "", ""
+  }
+  raw, err := base64.StdEncoding.DecodeString(base64Key)
+  if(err != nil) {
+    log.Fatalf("invalid base64 SSE-C key: %s", err)
   }
-  wg.Done()
+  sum := md5.Sum(raw)
+  return "AES256", base64Key, base64.StdEncoding.EncodeToString(sum[:])
 }
@@ -0,0 +1,127 @@
+package main
+
+import (
+  "reflect"
+  "testing"
+  "time"
+
+  "github.com/djannot/ecss3copy/backends"
+  "github.com/djannot/ecss3copy/state"
+)
+
+func TestReverseObjects(t *testing.T) {
+  objects := []backends.Object{
+    {Key: "a", VersionId: "3"},
+    {Key: "a", VersionId: "2"},
+    {Key: "a", VersionId: "1"},
+  }
+  got := reverseObjects(objects)
+  want := []backends.Object{
+    {Key: "a", VersionId: "1"},
+    {Key: "a", VersionId: "2"},
+    {Key: "a", VersionId: "3"},
+  }
+  if !reflect.DeepEqual(got, want) {
+    t.Fatalf("reverseObjects() = %v, want %v", got, want)
+  }
+}
+
+func TestReverseObjectsEmpty(t *testing.T) {
+  if got := reverseObjects(nil); len(got) != 0 {
+    t.Fatalf("reverseObjects(nil) = %v, want empty", got)
+  }
+}
+
+func TestSSECustomerHeadersEmpty(t *testing.T) {
+  algorithm, key, keyMD5 := sseCustomerHeaders("")
+  if algorithm != "" || key != "" || keyMD5 != "" {
+    t.Fatalf("sseCustomerHeaders(\"\") = (%q, %q, %q), want all empty", algorithm, key, keyMD5)
+  }
+}
+
+func TestSSECustomerHeaders(t *testing.T) {
+  base64Key := "MDEyMzQ1Njc4OTAxMjM0NTY3ODkwMTIzNDU2Nzg5MDE="
+  algorithm, key, keyMD5 := sseCustomerHeaders(base64Key)
+  if algorithm != "AES256" {
+    t.Errorf("algorithm = %q, want AES256", algorithm)
+  }
+  if key != base64Key {
+    t.Errorf("key = %q, want %q", key, base64Key)
+  }
+  wantKeyMD5 := "KYvwGXoFFJ42a2u2GDWhwQ=="
+  if keyMD5 != wantKeyMD5 {
+    t.Errorf("keyMD5 = %q, want %q", keyMD5, wantKeyMD5)
+  }
+}
+
+// withGlobals temporarily swaps the package globals shouldSkip reads, since
+// it's wired straight to the CLI-driven state without its own argument
+// struct. It restores them once the test finishes.
+func withGlobals(t *testing.T, resume bool, store *state.Store, only map[string]bool) {
+  t.Helper()
+  prevResume, prevStore, prevOnlyFrom := opts.Resume, stateStore, onlyFrom
+  opts.Resume, stateStore, onlyFrom = resume, store, only
+  t.Cleanup(func() {
+    opts.Resume, stateStore, onlyFrom = prevResume, prevStore, prevOnlyFrom
+  })
+}
+
+func TestShouldSkipOnlyFrom(t *testing.T) {
+  withGlobals(t, false, nil, map[string]bool{"keep": true})
+
+  if shouldSkip(backends.Object{Key: "drop"}) != true {
+    t.Error("shouldSkip() = false for a key not in --only-from, want true")
+  }
+  if shouldSkip(backends.Object{Key: "keep"}) != false {
+    t.Error("shouldSkip() = true for a key in --only-from, want false")
+  }
+}
+
+func TestShouldSkipResume(t *testing.T) {
+  store, err := state.Open(t.TempDir() + "/state.db")
+  if err != nil {
+    t.Fatalf("state.Open() error = %v", err)
+  }
+  defer store.Close()
+  if err := store.Put(state.Record{Key: "done", Size: 1, ETag: "etag", LastModified: "now", Status: state.Done}); err != nil {
+    t.Fatalf("Put() error = %v", err)
+  }
+  withGlobals(t, true, store, nil)
+
+  upToDate := backends.Object{Key: "done", Size: 1, ETag: "etag", LastModified: "now"}
+  if shouldSkip(upToDate) != true {
+    t.Error("shouldSkip() = false for an up-to-date Done record with --resume, want true")
+  }
+
+  changed := backends.Object{Key: "done", Size: 2, ETag: "etag", LastModified: "now"}
+  if shouldSkip(changed) != false {
+    t.Error("shouldSkip() = true for a changed source with --resume, want false")
+  }
+
+  unseen := backends.Object{Key: "new"}
+  if shouldSkip(unseen) != false {
+    t.Error("shouldSkip() = true for a key with no state record, want false")
+  }
+}
+
+func TestEffectiveConcurrency(t *testing.T) {
+  if got := effectiveConcurrency(true, 10); got != 1 {
+    t.Errorf("effectiveConcurrency(true, 10) = %d, want 1", got)
+  }
+  if got := effectiveConcurrency(false, 10); got != 10 {
+    t.Errorf("effectiveConcurrency(false, 10) = %d, want 10", got)
+  }
+}
+
+func TestBackoffWithJitter(t *testing.T) {
+  for attempt := 1; attempt <= 5; attempt++ {
+    base := 100 * time.Millisecond * time.Duration(1<<uint(attempt-1))
+    maxDelay := base + base/2
+    for i := 0; i < 20; i++ {
+      delay := backoffWithJitter(attempt)
+      if delay < base || delay > maxDelay {
+        t.Fatalf("backoffWithJitter(%d) = %s, want in [%s, %s]", attempt, delay, base, maxDelay)
+      }
+    }
+  }
+}
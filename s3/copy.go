@@ -0,0 +1,74 @@
+package s3
+
+import "net/http"
+
+// CopyOptions controls how CopyToNewBucket replicates an object, including
+// the ACL/metadata directive to apply and any server-side encryption to
+// request on the destination object or to supply for an SSE-C source.
+type CopyOptions struct {
+  ACL ACL
+  MetadataDirective string
+
+  // ServerSideEncryption is "AES256" or "aws:kms", applied to the
+  // destination object. Empty means don't request SSE.
+  ServerSideEncryption string
+  // SSEKMSKeyId is the KMS key id/ARN to use when ServerSideEncryption
+  // is "aws:kms". Ignored otherwise.
+  SSEKMSKeyId string
+
+  // SSECustomerAlgorithm/Key/KeyMD5 describe customer-provided encryption
+  // to apply to the destination object.
+  SSECustomerAlgorithm string
+  SSECustomerKey string
+  SSECustomerKeyMD5 string
+
+  // CopySourceSSECustomerAlgorithm/Key/KeyMD5 describe the customer key
+  // needed to read an SSE-C encrypted source object.
+  CopySourceSSECustomerAlgorithm string
+  CopySourceSSECustomerKey string
+  CopySourceSSECustomerKeyMD5 string
+
+  // SourceVersionId, when set, pins the copy source to a specific object
+  // version instead of the current one.
+  SourceVersionId string
+}
+
+// CopyToNewBucket copies sourceKey from sourceBucket into targetKey in b,
+// applying opts' ACL, metadata directive and encryption settings.
+func (b *Bucket) CopyToNewBucket(sourceKey, targetKey, sourceBucket string, opts CopyOptions) error {
+  headers := http.Header{}
+  copySource := "/" + sourceBucket + "/" + sourceKey
+  if opts.SourceVersionId != "" {
+    copySource += "?versionId=" + opts.SourceVersionId
+  }
+  headers.Set("x-amz-copy-source", copySource)
+  headers.Set("x-amz-acl", string(opts.ACL))
+  if opts.MetadataDirective != "" {
+    headers.Set("x-amz-metadata-directive", opts.MetadataDirective)
+  }
+
+  if opts.ServerSideEncryption != "" {
+    headers.Set("x-amz-server-side-encryption", opts.ServerSideEncryption)
+    if opts.ServerSideEncryption == "aws:kms" && opts.SSEKMSKeyId != "" {
+      headers.Set("x-amz-server-side-encryption-aws-kms-key-id", opts.SSEKMSKeyId)
+    }
+  }
+  if opts.SSECustomerAlgorithm != "" {
+    headers.Set("x-amz-server-side-encryption-customer-algorithm", opts.SSECustomerAlgorithm)
+    headers.Set("x-amz-server-side-encryption-customer-key", opts.SSECustomerKey)
+    headers.Set("x-amz-server-side-encryption-customer-key-MD5", opts.SSECustomerKeyMD5)
+  }
+  if opts.CopySourceSSECustomerAlgorithm != "" {
+    headers.Set("x-amz-copy-source-server-side-encryption-customer-algorithm", opts.CopySourceSSECustomerAlgorithm)
+    headers.Set("x-amz-copy-source-server-side-encryption-customer-key", opts.CopySourceSSECustomerKey)
+    headers.Set("x-amz-copy-source-server-side-encryption-customer-key-MD5", opts.CopySourceSSECustomerKeyMD5)
+  }
+
+  req := &request{
+    method: "PUT",
+    bucket: b.Name,
+    path: "/" + targetKey,
+    headers: headers,
+  }
+  return b.S3.do(req)
+}
@@ -0,0 +1,13 @@
+package s3
+
+// Del removes key from the bucket. When the bucket is versioned and no
+// specific version is targeted, this issues a new delete marker rather
+// than erasing history.
+func (b *Bucket) Del(key string) error {
+  req := &request{
+    method: "DELETE",
+    bucket: b.Name,
+    path: "/" + key,
+  }
+  return b.S3.do(req)
+}
@@ -0,0 +1,39 @@
+package s3
+
+import (
+  "io"
+  "net/http"
+)
+
+// GetReader opens a streaming read of key, returning its body and the
+// advertised content length. sseCAlgorithm/sseCKey/sseCKeyMD5 supply the
+// customer-provided key needed to read an SSE-C encrypted object; leave
+// them empty for objects that aren't SSE-C encrypted. The caller must close
+// the returned reader.
+func (b *Bucket) GetReader(key, sseCAlgorithm, sseCKey, sseCKeyMD5 string) (io.ReadCloser, int64, error) {
+  headers := http.Header{}
+  if sseCAlgorithm != "" {
+    headers.Set("x-amz-server-side-encryption-customer-algorithm", sseCAlgorithm)
+    headers.Set("x-amz-server-side-encryption-customer-key", sseCKey)
+    headers.Set("x-amz-server-side-encryption-customer-key-MD5", sseCKeyMD5)
+  }
+
+  req := &request{
+    bucket: b.Name,
+    path: "/" + key,
+    headers: headers,
+  }
+  httpReq, err := b.S3.prepare(req)
+  if err != nil {
+    return nil, 0, err
+  }
+  httpResp, err := b.S3.client.Do(httpReq)
+  if err != nil {
+    return nil, 0, err
+  }
+  if httpResp.StatusCode >= 300 {
+    defer httpResp.Body.Close()
+    return nil, 0, newError(httpResp)
+  }
+  return httpResp.Body, httpResp.ContentLength, nil
+}
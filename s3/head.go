@@ -0,0 +1,39 @@
+package s3
+
+// HeadResp is the subset of a HeadObject response this tool cares about.
+type HeadResp struct {
+  ServerSideEncryption string
+  SSEKMSKeyId string
+  ContentLength int64
+  ETag string
+  LastModified string
+}
+
+// Head issues a HeadObject request for key, returning its encryption state
+// and basic metadata.
+func (b *Bucket) Head(key string) (*HeadResp, error) {
+  req := &request{
+    method: "HEAD",
+    bucket: b.Name,
+    path: "/" + key,
+  }
+  httpReq, err := b.S3.prepare(req)
+  if err != nil {
+    return nil, err
+  }
+  httpResp, err := b.S3.client.Do(httpReq)
+  if err != nil {
+    return nil, err
+  }
+  defer httpResp.Body.Close()
+  if httpResp.StatusCode >= 300 {
+    return nil, newError(httpResp)
+  }
+  return &HeadResp{
+    ServerSideEncryption: httpResp.Header.Get("x-amz-server-side-encryption"),
+    SSEKMSKeyId: httpResp.Header.Get("x-amz-server-side-encryption-aws-kms-key-id"),
+    ContentLength: httpResp.ContentLength,
+    ETag: httpResp.Header.Get("ETag"),
+    LastModified: httpResp.Header.Get("Last-Modified"),
+  }, nil
+}
@@ -0,0 +1,28 @@
+package s3
+
+import "strconv"
+
+// ListResp is the parsed result of a bucket listing (the ListObjects API).
+type ListResp struct {
+  Contents []Key
+  IsTruncated bool
+  NextMarker string
+}
+
+// List lists up to maxKeys objects under prefix, starting after marker.
+func (b *Bucket) List(prefix, delim, marker string, maxKeys int) (*ListResp, error) {
+  req := &request{
+    bucket: b.Name,
+    params: map[string]string{
+      "prefix": prefix,
+      "delimiter": delim,
+      "marker": marker,
+      "max-keys": strconv.Itoa(maxKeys),
+    },
+  }
+  resp := &ListResp{}
+  if err := b.S3.query(req, resp); err != nil {
+    return nil, err
+  }
+  return resp, nil
+}
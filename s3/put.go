@@ -0,0 +1,33 @@
+package s3
+
+import "io"
+
+// PutReader uploads body (of the given size) to key, applying opts' ACL
+// and encryption settings. The object is always put in a single request,
+// whatever its size; there is no multipart upload here.
+func (b *Bucket) PutReader(key string, body io.Reader, size int64, opts CopyOptions) error {
+  headers := make(map[string][]string)
+  put := func(k, v string) {
+    if v != "" {
+      headers[k] = []string{v}
+    }
+  }
+  put("x-amz-acl", string(opts.ACL))
+  put("x-amz-server-side-encryption", opts.ServerSideEncryption)
+  if opts.ServerSideEncryption == "aws:kms" {
+    put("x-amz-server-side-encryption-aws-kms-key-id", opts.SSEKMSKeyId)
+  }
+  put("x-amz-server-side-encryption-customer-algorithm", opts.SSECustomerAlgorithm)
+  put("x-amz-server-side-encryption-customer-key", opts.SSECustomerKey)
+  put("x-amz-server-side-encryption-customer-key-MD5", opts.SSECustomerKeyMD5)
+
+  req := &request{
+    method: "PUT",
+    bucket: b.Name,
+    path: "/" + key,
+    headers: headers,
+    body: body,
+    bodySize: size,
+  }
+  return b.S3.do(req)
+}
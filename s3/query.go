@@ -0,0 +1,37 @@
+package s3
+
+import "strconv"
+
+// Entry is a single hit from an ECS metadata search query.
+type Entry struct {
+  ObjectName string
+  QueryMds []struct {
+    Type string
+    Key string
+    Value string
+  }
+}
+
+// QueryResp is the parsed result of an ECS metadata search query.
+type QueryResp struct {
+  EntryLists []Entry
+  NextMarker string
+}
+
+// Query runs the given ECS metadata search query, returning up to maxKeys
+// matching objects starting after marker.
+func (b *Bucket) Query(query, marker string, maxKeys int) (*QueryResp, error) {
+  req := &request{
+    bucket: b.Name,
+    params: map[string]string{
+      "query": query,
+      "marker": marker,
+      "max-keys": strconv.Itoa(maxKeys),
+    },
+  }
+  resp := &QueryResp{}
+  if err := b.S3.query(req, resp); err != nil {
+    return nil, err
+  }
+  return resp, nil
+}
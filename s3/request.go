@@ -0,0 +1,55 @@
+package s3
+
+import (
+  "encoding/xml"
+  "io"
+  "net/http"
+)
+
+// request describes a single REST call against a bucket: the sub-resource
+// being hit, its query parameters and any headers that need to be signed
+// along with the request.
+type request struct {
+  method string
+  bucket string
+  path string
+  params map[string]string
+  // subResource flags a valueless query-string sub-resource that must be
+  // present in both the request URL and the signed CanonicalizedResource,
+  // e.g. "versions" for ListObjectVersions. Empty means none.
+  subResource string
+  headers http.Header
+  // body and bodySize carry a request body (e.g. PutReader's object data)
+  // through to prepare, which sets it on the *http.Request along with an
+  // explicit Content-Length. Both are zero for requests with no body.
+  body io.Reader
+  bodySize int64
+}
+
+// query performs req against the bucket's endpoint and unmarshals the XML
+// response body into resp.
+func (s3 *S3) query(req *request, resp interface{}) error {
+  httpReq, err := s3.prepare(req)
+  if err != nil {
+    return err
+  }
+  httpResp, err := s3.client.Do(httpReq)
+  if err != nil {
+    return err
+  }
+  defer httpResp.Body.Close()
+  if httpResp.StatusCode >= 300 {
+    return newError(httpResp)
+  }
+  if resp == nil {
+    return nil
+  }
+  return xml.NewDecoder(httpResp.Body).Decode(resp)
+}
+
+// do performs req and discards the response body, returning only the error
+// (if any). It's used for calls like CopyObject and Delete that don't need
+// their response parsed beyond a status check.
+func (s3 *S3) do(req *request) error {
+  return s3.query(req, nil)
+}
@@ -0,0 +1,63 @@
+package s3
+
+import (
+  "net/http"
+  "github.com/mitchellh/goamz/aws"
+)
+
+// S3 is a client for the ECS S3-compatible API. It wraps a goamz aws.Auth
+// and aws.Region so callers can point it at an ECS endpoint instead of AWS.
+type S3 struct {
+  auth aws.Auth
+  region aws.Region
+  client *http.Client
+}
+
+// New creates an S3 client bound to the given credentials and region.
+func New(auth aws.Auth, region aws.Region) *S3 {
+  return &S3{
+    auth: auth,
+    region: region,
+    client: http.DefaultClient,
+  }
+}
+
+// Bucket returns a handle to the named bucket on this client.
+func (s3 *S3) Bucket(name string) *Bucket {
+  return &Bucket{
+    S3: s3,
+    Name: name,
+  }
+}
+
+// Bucket represents a single bucket reachable through an S3 client.
+type Bucket struct {
+  *S3
+  Name string
+}
+
+// Key describes a single object as returned by a bucket listing.
+type Key struct {
+  Key string
+  LastModified string
+  Size int64
+  ETag string
+  // VersionId identifies the specific version of the object this Key
+  // refers to. It is empty for buckets without versioning, or when the
+  // listing was produced by List rather than Versions.
+  VersionId string
+  // IsDeleteMarker is set when the entry came from the DeleteMarkers
+  // section of a ListObjectVersions response rather than Versions.
+  IsDeleteMarker bool
+}
+
+// ACL is one of the canned S3 access control list values.
+type ACL string
+
+const (
+  Private ACL = "private"
+  PublicRead ACL = "public-read"
+  PublicReadWrite ACL = "public-read-write"
+  BucketOwnerRead ACL = "bucket-owner-read"
+  BucketOwnerFull ACL = "bucket-owner-full-control"
+)
@@ -0,0 +1,169 @@
+package s3
+
+import (
+  "crypto/hmac"
+  "crypto/sha1"
+  "encoding/base64"
+  "fmt"
+  "net/http"
+  "net/url"
+  "sort"
+  "strings"
+  "time"
+  "github.com/mitchellh/goamz/aws"
+)
+
+// canonicalSubResources are the query-string sub-resources that Signature
+// Version 2 requires to be folded into the CanonicalizedResource. Without
+// this, a request's sub-resource (e.g. ?versions, ECS's ?query, or a
+// version-pinned ?versionId=) could be stripped or rewritten in transit
+// without invalidating the signature.
+var canonicalSubResources = map[string]bool{
+  "acl": true, "lifecycle": true, "location": true, "logging": true,
+  "notification": true, "partNumber": true, "policy": true,
+  "requestPayment": true, "torrent": true, "uploadId": true, "uploads": true,
+  "versionId": true, "versioning": true, "versions": true, "website": true,
+  "query": true,
+  "response-content-type": true, "response-content-language": true,
+  "response-expires": true, "response-cache-control": true,
+  "response-content-disposition": true, "response-content-encoding": true,
+}
+
+// sign adds the Date and Authorization headers the ECS S3 API expects,
+// computed per AWS Signature Version 2: an HMAC-SHA1 of the canonicalized
+// request, keyed on the auth's secret key. The canonicalized form covers
+// the HTTP method, Content-MD5/Content-Type, Date, every x-amz-* header
+// (including the SSE/SSE-C headers CopyToNewBucket, PutReader and GetReader
+// set) and the canonicalized
+// sub-resource, so none of them can be altered in transit without
+// invalidating the signature.
+func sign(auth aws.Auth, req *request, httpReq *http.Request) {
+  date := time.Now().UTC().Format(http.TimeFormat)
+  httpReq.Header.Set("Date", date)
+  if auth.Token != "" {
+    httpReq.Header.Set("x-amz-security-token", auth.Token)
+  }
+
+  stringToSign := strings.Join([]string{
+    httpReq.Method,
+    httpReq.Header.Get("Content-MD5"),
+    httpReq.Header.Get("Content-Type"),
+    date,
+  }, "\n") + "\n" + canonicalizedAmzHeaders(httpReq.Header) + canonicalizedResource(req)
+
+  mac := hmac.New(sha1.New, []byte(auth.SecretKey))
+  mac.Write([]byte(stringToSign))
+  digest := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+  httpReq.Header.Set("Authorization", fmt.Sprintf("AWS %s:%s", auth.AccessKey, digest))
+}
+
+// canonicalizedAmzHeaders is every x-amz-* header, lower-cased, sorted and
+// joined as "key:value\n", per the CanonicalizedAmzHeaders step of the
+// Signature Version 2 spec. Multiple values for the same header are
+// comma-joined onto a single line rather than repeated.
+func canonicalizedAmzHeaders(headers http.Header) string {
+  var keys []string
+  for k := range headers {
+    if lower := strings.ToLower(k); strings.HasPrefix(lower, "x-amz-") {
+      keys = append(keys, lower)
+    }
+  }
+  sort.Strings(keys)
+
+  var canonical string
+  for _, k := range keys {
+    canonical += k + ":" + strings.Join(headers[http.CanonicalHeaderKey(k)], ",") + "\n"
+  }
+  return canonical
+}
+
+// canonicalizedResource is "/bucket/key" plus any sub-resource query
+// parameters, sorted and joined as AWS's Signature Version 2 spec calls the
+// CanonicalizedResource, e.g. "/bucket?versions" or "/bucket?query=foo".
+func canonicalizedResource(req *request) string {
+  resource := "/" + req.bucket + req.path
+
+  values := map[string]string{}
+  if req.subResource != "" && canonicalSubResources[req.subResource] {
+    values[req.subResource] = ""
+  }
+  for k, v := range req.params {
+    if v != "" && canonicalSubResources[k] {
+      values[k] = v
+    }
+  }
+  if len(values) == 0 {
+    return resource
+  }
+
+  var keys []string
+  for k := range values {
+    keys = append(keys, k)
+  }
+  sort.Strings(keys)
+
+  var parts []string
+  for _, k := range keys {
+    if values[k] == "" {
+      parts = append(parts, k)
+    } else {
+      parts = append(parts, k+"="+values[k])
+    }
+  }
+  return resource + "?" + strings.Join(parts, "&")
+}
+
+// Error wraps an S3 error response body.
+type Error struct {
+  StatusCode int
+  Code string
+  Message string
+  RequestId string
+}
+
+func (e *Error) Error() string {
+  return fmt.Sprintf("s3: %d %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+func newError(resp *http.Response) error {
+  return &Error{StatusCode: resp.StatusCode}
+}
+
+// prepare builds the signed *http.Request for req against the S3 client's
+// configured endpoint, signing it with the client's aws.Auth.
+func (s3 *S3) prepare(req *request) (*http.Request, error) {
+  u := url.URL{
+    Scheme: "https",
+    Host: s3.region.S3Endpoint,
+    Path: "/" + req.bucket + req.path,
+  }
+  q := u.Query()
+  for k, v := range req.params {
+    if v != "" {
+      q.Set(k, v)
+    }
+  }
+  if req.subResource != "" {
+    q.Set(req.subResource, "")
+  }
+  u.RawQuery = q.Encode()
+
+  method := req.method
+  if method == "" {
+    method = "GET"
+  }
+  httpReq, err := http.NewRequest(method, u.String(), req.body)
+  if err != nil {
+    return nil, err
+  }
+  if req.body != nil {
+    httpReq.ContentLength = req.bodySize
+  }
+  for k, vs := range req.headers {
+    for _, v := range vs {
+      httpReq.Header.Add(k, v)
+    }
+  }
+  sign(s3.auth, req, httpReq)
+  return httpReq, nil
+}
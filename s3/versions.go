@@ -0,0 +1,43 @@
+package s3
+
+import "strconv"
+
+// VersionKey is a single entry from a ListObjectVersions response: either a
+// real object version or a delete marker, in which case IsDeleteMarker is
+// set and the object has no content at that version.
+type VersionKey struct {
+  Key
+  IsLatest bool
+}
+
+// VersionsResp is the parsed result of a ListObjectVersions call.
+type VersionsResp struct {
+  Versions []VersionKey
+  DeleteMarkers []VersionKey
+  IsTruncated bool
+  NextKeyMarker string
+  NextVersionIdMarker string
+}
+
+// Versions lists up to maxKeys object versions and delete markers under
+// prefix, resuming from keyMarker/versionIdMarker.
+func (b *Bucket) Versions(prefix, keyMarker, versionIdMarker string, maxKeys int) (*VersionsResp, error) {
+  req := &request{
+    bucket: b.Name,
+    subResource: "versions",
+    params: map[string]string{
+      "prefix": prefix,
+      "key-marker": keyMarker,
+      "version-id-marker": versionIdMarker,
+      "max-keys": strconv.Itoa(maxKeys),
+    },
+  }
+  resp := &VersionsResp{}
+  if err := b.S3.query(req, resp); err != nil {
+    return nil, err
+  }
+  for i := range resp.DeleteMarkers {
+    resp.DeleteMarkers[i].IsDeleteMarker = true
+  }
+  return resp, nil
+}
@@ -0,0 +1,53 @@
+package state
+
+import (
+  "encoding/csv"
+  "encoding/json"
+  "os"
+  "strconv"
+  "strings"
+)
+
+// WriteReport writes every Failed record in s to path, as JSON or CSV
+// depending on the file extension (.csv, JSON otherwise).
+func (s *Store) WriteReport(path string) error {
+  failures, err := s.Failed()
+  if err != nil {
+    return err
+  }
+  if strings.HasSuffix(path, ".csv") {
+    return writeCSVReport(path, failures)
+  }
+  return writeJSONReport(path, failures)
+}
+
+func writeJSONReport(path string, failures []Record) error {
+  file, err := os.Create(path)
+  if err != nil {
+    return err
+  }
+  defer file.Close()
+  encoder := json.NewEncoder(file)
+  encoder.SetIndent("", "  ")
+  return encoder.Encode(failures)
+}
+
+func writeCSVReport(path string, failures []Record) error {
+  file, err := os.Create(path)
+  if err != nil {
+    return err
+  }
+  defer file.Close()
+
+  writer := csv.NewWriter(file)
+  defer writer.Flush()
+  if err := writer.Write([]string{"key", "attempts", "error"}); err != nil {
+    return err
+  }
+  for _, record := range failures {
+    if err := writer.Write([]string{record.Key, strconv.Itoa(record.Attempts), record.Error}); err != nil {
+      return err
+    }
+  }
+  return nil
+}
@@ -0,0 +1,141 @@
+// Package state tracks per-key copy progress in a local BoltDB file, so a
+// large migration can be resumed, delta-synced, or previewed with --dry-run
+// instead of re-listing and re-copying everything from scratch.
+package state
+
+import (
+  "encoding/json"
+  "time"
+
+  bolt "go.etcd.io/bbolt"
+)
+
+// Status is the outcome of the last attempt to copy a key.
+type Status string
+
+const (
+  Pending Status = "pending"
+  Done Status = "done"
+  Failed Status = "failed"
+  Skipped Status = "skipped"
+)
+
+var objectsBucket = []byte("objects")
+
+// Record is everything the store remembers about one source key.
+type Record struct {
+  Key string
+  LastModified string
+  Size int64
+  ETag string
+  SourceVersionId string
+  Status Status
+  Attempts int
+  Error string
+  UpdatedAt time.Time
+}
+
+// Store is a BoltDB-backed checkpoint database, one Record per source key
+// (per key and version when versions are copied; see recordKey).
+type Store struct {
+  db *bolt.DB
+}
+
+// Open opens (creating if necessary) the state file at path.
+func Open(path string) (*Store, error) {
+  db, err := bolt.Open(path, 0600, nil)
+  if err != nil {
+    return nil, err
+  }
+  err = db.Update(func(tx *bolt.Tx) error {
+    _, err := tx.CreateBucketIfNotExists(objectsBucket)
+    return err
+  })
+  if err != nil {
+    db.Close()
+    return nil, err
+  }
+  return &Store{db: db}, nil
+}
+
+func (s *Store) Close() error {
+  return s.db.Close()
+}
+
+// recordKey is the BoltDB key for a Record: the source key alone for a
+// plain (unversioned) copy, or key+versionId when --versions is in play, so
+// that copying several versions of the same key in one run doesn't have
+// each version's outcome overwrite the last.
+func recordKey(key, versionId string) string {
+  if versionId == "" {
+    return key
+  }
+  return key + "\x00" + versionId
+}
+
+// Get returns the stored Record for key/versionId, if any. versionId is
+// empty for a plain (unversioned) copy.
+func (s *Store) Get(key, versionId string) (*Record, bool, error) {
+  var record Record
+  found := false
+  err := s.db.View(func(tx *bolt.Tx) error {
+    data := tx.Bucket(objectsBucket).Get([]byte(recordKey(key, versionId)))
+    if data == nil {
+      return nil
+    }
+    found = true
+    return json.Unmarshal(data, &record)
+  })
+  if err != nil {
+    return nil, false, err
+  }
+  if !found {
+    return nil, false, nil
+  }
+  return &record, true, nil
+}
+
+// Put upserts record, keyed by record.Key and record.SourceVersionId.
+func (s *Store) Put(record Record) error {
+  record.UpdatedAt = time.Now()
+  data, err := json.Marshal(record)
+  if err != nil {
+    return err
+  }
+  return s.db.Update(func(tx *bolt.Tx) error {
+    return tx.Bucket(objectsBucket).Put([]byte(recordKey(record.Key, record.SourceVersionId)), data)
+  })
+}
+
+// Each calls fn with every stored Record. Iteration stops at the first error
+// fn returns.
+func (s *Store) Each(fn func(Record) error) error {
+  return s.db.View(func(tx *bolt.Tx) error {
+    return tx.Bucket(objectsBucket).ForEach(func(k, v []byte) error {
+      var record Record
+      if err := json.Unmarshal(v, &record); err != nil {
+        return err
+      }
+      return fn(record)
+    })
+  })
+}
+
+// Failed returns every Record currently marked Failed.
+func (s *Store) Failed() ([]Record, error) {
+  var failures []Record
+  err := s.Each(func(r Record) error {
+    if r.Status == Failed {
+      failures = append(failures, r)
+    }
+    return nil
+  })
+  return failures, err
+}
+
+// UpToDate reports whether a previously recorded Done record still matches
+// the source's current size/ETag/last-modified, i.e. the source hasn't
+// changed since it was copied.
+func (r Record) UpToDate(size int64, etag, lastModified string) bool {
+  return r.Status == Done && r.Size == size && r.ETag == etag && r.LastModified == lastModified
+}
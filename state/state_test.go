@@ -0,0 +1,84 @@
+package state
+
+import "testing"
+
+func TestRecordUpToDate(t *testing.T) {
+  done := Record{Status: Done, Size: 42, ETag: "abc", LastModified: "2026-01-01"}
+
+  if !done.UpToDate(42, "abc", "2026-01-01") {
+    t.Error("UpToDate() = false for a matching Done record, want true")
+  }
+  if done.UpToDate(43, "abc", "2026-01-01") {
+    t.Error("UpToDate() = true with a different size, want false")
+  }
+  if done.UpToDate(42, "different-etag", "2026-01-01") {
+    t.Error("UpToDate() = true with a different ETag, want false")
+  }
+  if done.UpToDate(42, "abc", "2026-02-01") {
+    t.Error("UpToDate() = true with a different last-modified, want false")
+  }
+
+  pending := Record{Status: Pending, Size: 42, ETag: "abc", LastModified: "2026-01-01"}
+  if pending.UpToDate(42, "abc", "2026-01-01") {
+    t.Error("UpToDate() = true for a non-Done record, want false")
+  }
+}
+
+func TestStorePutGetRoundTrip(t *testing.T) {
+  store, err := Open(t.TempDir() + "/state.db")
+  if err != nil {
+    t.Fatalf("Open() error = %v", err)
+  }
+  defer store.Close()
+
+  record := Record{Key: "foo", Size: 10, ETag: "etag-1", Status: Done}
+  if err := store.Put(record); err != nil {
+    t.Fatalf("Put() error = %v", err)
+  }
+
+  got, found, err := store.Get("foo", "")
+  if err != nil {
+    t.Fatalf("Get() error = %v", err)
+  }
+  if !found {
+    t.Fatal("Get() found = false, want true")
+  }
+  if got.ETag != "etag-1" || got.Status != Done {
+    t.Fatalf("Get() = %+v, want ETag=etag-1 Status=Done", got)
+  }
+
+  if _, found, err := store.Get("missing", ""); err != nil || found {
+    t.Fatalf("Get(missing) = (found=%v, err=%v), want (false, nil)", found, err)
+  }
+}
+
+func TestStoreKeysVersionsSeparately(t *testing.T) {
+  store, err := Open(t.TempDir() + "/state.db")
+  if err != nil {
+    t.Fatalf("Open() error = %v", err)
+  }
+  defer store.Close()
+
+  if err := store.Put(Record{Key: "foo", SourceVersionId: "v1", Status: Done}); err != nil {
+    t.Fatalf("Put(v1) error = %v", err)
+  }
+  if err := store.Put(Record{Key: "foo", SourceVersionId: "v2", Status: Failed}); err != nil {
+    t.Fatalf("Put(v2) error = %v", err)
+  }
+
+  v1, found, err := store.Get("foo", "v1")
+  if err != nil || !found {
+    t.Fatalf("Get(v1) = (found=%v, err=%v), want (true, nil)", found, err)
+  }
+  if v1.Status != Done {
+    t.Fatalf("Get(v1).Status = %v, want Done", v1.Status)
+  }
+
+  v2, found, err := store.Get("foo", "v2")
+  if err != nil || !found {
+    t.Fatalf("Get(v2) = (found=%v, err=%v), want (true, nil)", found, err)
+  }
+  if v2.Status != Failed {
+    t.Fatalf("Get(v2).Status = %v, want Failed", v2.Status)
+  }
+}